@@ -0,0 +1,169 @@
+// Copyright 2016 Martin Hebnes Pedersen (LA5NTA). All rights reserved.
+// Use of this source code is governed by the MIT-license that can be
+// found in the LICENSE file.
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// addressBook maps an alias name to its expansion, a list of one or more
+// targets. A target is either a literal address (a
+// callsign, or an SMTP gateway address as "SMTP:user@host") or the name of
+// another alias, which lets groups be built out of other aliases.
+type addressBook map[string][]string
+
+// addressBookPath returns the path to the address book file under Pat's
+// config dir.
+func addressBookPath() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "pat", "addressbook.json"), nil
+}
+
+// loadAddressBook reads the address book from disk. A missing file is not
+// an error; it simply yields an empty address book.
+func loadAddressBook() (addressBook, error) {
+	path, err := addressBookPath()
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return addressBook{}, nil
+	} else if err != nil {
+		return nil, err
+	}
+	book := make(addressBook)
+	if err := json.Unmarshal(data, &book); err != nil {
+		return nil, fmt.Errorf("invalid address book %s: %w", path, err)
+	}
+	return book, nil
+}
+
+// save writes the address book to disk, creating the config dir if needed.
+func (b addressBook) save() error {
+	path, err := addressBookPath()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(b, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0o644)
+}
+
+// expandAddresses expands every token in toks against book, in order,
+// dropping duplicates from the result. Tokens that aren't known aliases are
+// left untouched, so raw callsigns and SMTP addresses keep working.
+func expandAddresses(book addressBook, toks []string) []string {
+	out := make([]string, 0, len(toks))
+	seen := make(map[string]bool, len(toks))
+	for _, tok := range toks {
+		for _, addr := range expandAlias(book, tok, nil) {
+			if seen[addr] {
+				continue
+			}
+			seen[addr] = true
+			out = append(out, addr)
+		}
+	}
+	return out
+}
+
+// expandAlias recursively expands tok against book. seen guards against
+// alias cycles: once a name has been expanded on the current path, hitting
+// it again means the address book is misconfigured, so we warn and drop it
+// rather than silently sending to the alias name itself.
+func expandAlias(book addressBook, tok string, seen map[string]bool) []string {
+	if seen[tok] {
+		fmt.Fprintf(os.Stderr, "WARNING: address book alias cycle detected at %q; dropping\n", tok)
+		return nil
+	}
+
+	targets, ok := book[tok]
+	if !ok {
+		return []string{tok}
+	}
+	seen = cloneSeen(seen)
+	seen[tok] = true
+
+	out := make([]string, 0, len(targets))
+	for _, t := range targets {
+		out = append(out, expandAlias(book, t, seen)...)
+	}
+	return out
+}
+
+func cloneSeen(seen map[string]bool) map[string]bool {
+	clone := make(map[string]bool, len(seen)+1)
+	for k, v := range seen {
+		clone[k] = v
+	}
+	return clone
+}
+
+// aliasCommand implements the `pat alias` subcommands for managing the
+// address book: add, remove and list.
+func aliasCommand(ctx context.Context, args []string) {
+	if len(args) == 0 {
+		fmt.Fprintln(os.Stderr, "ERROR: Missing alias subcommand (add, remove, list)")
+		os.Exit(1)
+	}
+
+	book, err := loadAddressBook()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "ERROR: failed to load address book: %v\n", err)
+		os.Exit(1)
+	}
+
+	switch cmd, rest := args[0], args[1:]; cmd {
+	case "add":
+		if len(rest) < 2 {
+			fmt.Fprintln(os.Stderr, "ERROR: Usage: pat alias add <name> <target>...")
+			os.Exit(1)
+		}
+		name, targets := rest[0], rest[1:]
+		book[name] = targets
+		if err := book.save(); err != nil {
+			fmt.Fprintf(os.Stderr, "ERROR: failed to save address book: %v\n", err)
+			os.Exit(1)
+		}
+	case "remove":
+		if len(rest) != 1 {
+			fmt.Fprintln(os.Stderr, "ERROR: Usage: pat alias remove <name>")
+			os.Exit(1)
+		}
+		delete(book, rest[0])
+		if err := book.save(); err != nil {
+			fmt.Fprintf(os.Stderr, "ERROR: failed to save address book: %v\n", err)
+			os.Exit(1)
+		}
+	case "list":
+		names := make([]string, 0, len(book))
+		for name := range book {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			fmt.Printf("%s = %s\n", name, strings.Join(book[name], ","))
+		}
+	default:
+		fmt.Fprintf(os.Stderr, "ERROR: Unknown alias subcommand %q (add, remove, list)\n", cmd)
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,63 @@
+// Copyright 2016 Martin Hebnes Pedersen (LA5NTA). All rights reserved.
+// Use of this source code is governed by the MIT-license that can be
+// found in the LICENSE file.
+
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestExpandAddresses(t *testing.T) {
+	book := addressBook{
+		"sysop":   {"N0CALL"},
+		"relay":   {"SMTP:relay@winlink.org"},
+		"clubnet": {"sysop", "relay"},
+		"nested":  {"clubnet", "N1CALL"},
+	}
+
+	tests := []struct {
+		name string
+		toks []string
+		want []string
+	}{
+		{"literal callsign passes through unchanged", []string{"N2CALL"}, []string{"N2CALL"}},
+		{"single alias expands", []string{"sysop"}, []string{"N0CALL"}},
+		{"group expands to all members", []string{"clubnet"}, []string{"N0CALL", "SMTP:relay@winlink.org"}},
+		{"nested group recurses", []string{"nested"}, []string{"N0CALL", "SMTP:relay@winlink.org", "N1CALL"}},
+		{"duplicates across tokens are dropped", []string{"sysop", "clubnet"}, []string{"N0CALL", "SMTP:relay@winlink.org"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := expandAddresses(book, tt.toks)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("expandAddresses(%v) = %v, want %v", tt.toks, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestExpandAddressesCycle(t *testing.T) {
+	book := addressBook{
+		"a": {"b"},
+		"b": {"a"},
+	}
+
+	got := expandAddresses(book, []string{"a"})
+	if len(got) != 0 {
+		t.Errorf("expandAddresses with a cycle = %v, want no addresses (alias name must not be sent to)", got)
+	}
+}
+
+func TestExpandAddressesSelfCycle(t *testing.T) {
+	book := addressBook{
+		"loop": {"loop", "N0CALL"},
+	}
+
+	got := expandAddresses(book, []string{"loop"})
+	want := []string{"N0CALL"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("expandAddresses with a self-cycle = %v, want %v", got, want)
+	}
+}
@@ -9,12 +9,15 @@ import (
 	"bufio"
 	"bytes"
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
 	"log"
+	"net/textproto"
 	"os"
 	"path/filepath"
+	"regexp"
 	"strings"
 	"time"
 
@@ -24,9 +27,29 @@ import (
 	"github.com/la5nta/pat/internal/editor"
 )
 
-func composeMessageHeader(inReplyToMsg *fbb.Message) *fbb.Message {
+// composeHeaderMode tells composeMessageHeader which defaults to offer for
+// To/Cc and Subject while prompting the user.
+type composeHeaderMode int
+
+const (
+	composeHeaderModeNew composeHeaderMode = iota
+	composeHeaderModeReply
+	composeHeaderModeForward
+)
+
+// composeMessageHeader prompts the user for From/To/Cc/Subject and returns a
+// new draft message. srcMsg is the message being replied to or forwarded
+// (nil for a plain new message); mode decides how srcMsg is used to default
+// the prompts.
+func composeMessageHeader(mode composeHeaderMode, srcMsg *fbb.Message) *fbb.Message {
 	msg := fbb.NewMessage(fbb.Private, fOptions.MyCall)
 
+	book, err := loadAddressBook()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "WARNING: failed to load address book: %v\n", err)
+		book = addressBook{}
+	}
+
 	fmt.Printf(`From [%s]: `, fOptions.MyCall)
 	from := readLine()
 	if from == "" {
@@ -34,23 +57,25 @@ func composeMessageHeader(inReplyToMsg *fbb.Message) *fbb.Message {
 	}
 	msg.SetFrom(from)
 
+	replyCand := mode == composeHeaderModeReply && srcMsg != nil
+
 	fmt.Print(`To`)
-	if inReplyToMsg != nil {
-		fmt.Printf(" [%s]", inReplyToMsg.From())
+	if replyCand {
+		fmt.Printf(" [%s]", srcMsg.From())
 	}
 	fmt.Printf(": ")
 	to := readLine()
-	if to == "" && inReplyToMsg != nil {
-		msg.AddTo(inReplyToMsg.From().String())
+	if to == "" && replyCand {
+		msg.AddTo(srcMsg.From().String())
 	} else {
-		for _, addr := range strings.FieldsFunc(to, SplitFunc) {
+		for _, addr := range expandAddresses(book, strings.FieldsFunc(to, SplitFunc)) {
 			msg.AddTo(addr)
 		}
 	}
 
 	ccCand := make([]fbb.Address, 0)
-	if inReplyToMsg != nil {
-		for _, addr := range append(inReplyToMsg.To(), inReplyToMsg.Cc()...) {
+	if replyCand {
+		for _, addr := range append(srcMsg.To(), srcMsg.Cc()...) {
 			if !addr.EqualString(fOptions.MyCall) {
 				ccCand = append(ccCand, addr)
 			}
@@ -58,19 +83,19 @@ func composeMessageHeader(inReplyToMsg *fbb.Message) *fbb.Message {
 	}
 
 	fmt.Printf("Cc (! to remove cc's)")
-	if inReplyToMsg != nil {
+	if replyCand {
 		fmt.Printf(" %s", ccCand)
 	}
 	fmt.Print(`: `)
 	cc := readLine()
 	if cc == "!" {
 
-	} else if cc == "" && inReplyToMsg != nil {
+	} else if cc == "" && replyCand {
 		for _, addr := range ccCand {
 			msg.AddCc(addr.String())
 		}
 	} else {
-		for _, addr := range strings.FieldsFunc(cc, SplitFunc) {
+		for _, addr := range expandAddresses(book, strings.FieldsFunc(cc, SplitFunc)) {
 			msg.AddCc(addr)
 		}
 	}
@@ -87,11 +112,27 @@ func composeMessageHeader(inReplyToMsg *fbb.Message) *fbb.Message {
 		os.Exit(1)
 	}
 
+	subjectPrefix := ""
+	switch mode {
+	case composeHeaderModeReply:
+		subjectPrefix = "Re:"
+	case composeHeaderModeForward:
+		subjectPrefix = "Fwd:"
+	}
+
 	fmt.Print(`Subject: `)
-	if inReplyToMsg != nil {
-		subject := strings.TrimSpace(strings.TrimPrefix(inReplyToMsg.Subject(), "Re:"))
-		subject = fmt.Sprintf("Re:%s", subject)
-		fmt.Println(subject)
+	if srcMsg != nil && subjectPrefix != "" {
+		subject := strings.TrimSpace(strings.TrimPrefix(srcMsg.Subject(), subjectPrefix))
+		subject = fmt.Sprintf("%s %s", subjectPrefix, subject)
+		if mode == composeHeaderModeForward {
+			// A forward's subject is user-chosen, with "Fwd: ..." as default.
+			fmt.Printf("[%s] ", subject)
+			if s := readLine(); s != "" {
+				subject = s
+			}
+		} else {
+			fmt.Println(subject)
+		}
 		msg.SetSubject(subject)
 	} else {
 		msg.SetSubject(readLine())
@@ -104,6 +145,66 @@ func composeMessageHeader(inReplyToMsg *fbb.Message) *fbb.Message {
 	return msg
 }
 
+// composeMessageHeaderNonInteractive builds a draft message header the same
+// way composeMessageHeader does, but from already-parsed CLI values instead
+// of interactive prompts. It's used by --redirect/--forward under --dry-run,
+// where there's no tty to answer composeMessageHeader's questions.
+// Recipients/ccs are expected to already have gone through address book
+// expansion.
+func composeMessageHeaderNonInteractive(mode composeHeaderMode, srcMsg *fbb.Message, from, subject string, recipients, ccs []string) *fbb.Message {
+	msg := fbb.NewMessage(fbb.Private, fOptions.MyCall)
+	if from == "" {
+		from = fOptions.MyCall
+	}
+	msg.SetFrom(from)
+
+	replyCand := mode == composeHeaderModeReply && srcMsg != nil
+
+	if len(recipients) == 0 && replyCand {
+		msg.AddTo(srcMsg.From().String())
+	} else {
+		for _, addr := range recipients {
+			msg.AddTo(addr)
+		}
+	}
+
+	if len(ccs) == 0 && replyCand {
+		for _, addr := range append(srcMsg.To(), srcMsg.Cc()...) {
+			if !addr.EqualString(fOptions.MyCall) {
+				msg.AddCc(addr.String())
+			}
+		}
+	} else {
+		for _, addr := range ccs {
+			msg.AddCc(addr)
+		}
+	}
+
+	if len(msg.Receivers()) == 0 {
+		fmt.Fprint(os.Stderr, "ERROR: Message must have at least one recipient; use recipient args, --to or --cc with --dry-run\n")
+		os.Exit(1)
+	}
+
+	subjectPrefix := ""
+	switch mode {
+	case composeHeaderModeReply:
+		subjectPrefix = "Re:"
+	case composeHeaderModeForward:
+		subjectPrefix = "Fwd:"
+	}
+
+	if subject == "" && srcMsg != nil && subjectPrefix != "" {
+		subject = strings.TrimSpace(strings.TrimPrefix(srcMsg.Subject(), subjectPrefix))
+		subject = fmt.Sprintf("%s %s", subjectPrefix, subject)
+	}
+	if subject == "" {
+		subject = "<No subject>"
+	}
+	msg.SetSubject(subject)
+
+	return msg
+}
+
 func composeMessage(ctx context.Context, args []string) {
 	set := pflag.NewFlagSet("compose", pflag.ExitOnError)
 	// From default is --mycall but it can be overriden with -r
@@ -115,16 +216,54 @@ func composeMessage(ctx context.Context, args []string) {
 	template := set.StringP("template", "", "", "")
 	inReplyTo := set.StringP("in-reply-to", "", "", "")
 	redirect := set.StringP("redirect", "", "", "")
+	forward := set.StringP("forward", "", "", "")
+	to := set.StringArrayP("to", "t", nil, "")
+	attachAll := set.BoolP("attach-all", "A", false, "")
+	attachFull := set.BoolP("attach-full", "F", false, "")
+	headerFlags := set.StringArrayP("header", "H", nil, "")
+	fieldFlags := set.StringArrayP("field", "", nil, "")
+	dryRun := set.BoolP("dry-run", "", false, "")
+	jsonOut := set.BoolP("json", "", false, "")
 	set.Parse(args)
 
-	// Only allow either "in-reply-to" or "redirect"
-	if *inReplyTo != "" && *redirect != "" {
-		fmt.Fprint(os.Stderr, "ERROR: Only use one of the arguments 'in-reply-to' or 'redirect'!\n")
+	if *dryRun && !*jsonOut {
+		fmt.Fprint(os.Stderr, "ERROR: --dry-run currently requires --json!\n")
 		os.Exit(1)
 	}
 
-	// Remaining args are recipients
-	recipients := []string{}
+	extraHeaders, err := parseHeaderFlags(*headerFlags)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "ERROR: %v\n", err)
+		os.Exit(1)
+	}
+
+	fields, err := parseFieldFlags(*fieldFlags)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "ERROR: %v\n", err)
+		os.Exit(1)
+	}
+
+	// Only allow one of "in-reply-to", "redirect" or "forward"
+	if n := boolsToInt(*inReplyTo != "", *redirect != "", *forward != ""); n > 1 {
+		fmt.Fprint(os.Stderr, "ERROR: Only use one of the arguments 'in-reply-to', 'redirect' or 'forward'!\n")
+		os.Exit(1)
+	}
+
+	// -A/--attach-all and -F/--attach-full are mutually exclusive, and only
+	// make sense together with --forward.
+	if *attachAll && *attachFull {
+		fmt.Fprint(os.Stderr, "ERROR: Only use one of the arguments 'attach-all' or 'attach-full'!\n")
+		os.Exit(1)
+	}
+	if (*attachAll || *attachFull) && *forward == "" {
+		fmt.Fprint(os.Stderr, "ERROR: 'attach-all' and 'attach-full' are only valid together with 'forward'!\n")
+		os.Exit(1)
+	}
+
+	// Remaining args are recipients, same as --to (the latter mainly exists
+	// so --redirect/--forward have a recipient flag to use under --dry-run,
+	// where there's no tty to prompt for To).
+	recipients := append([]string{}, *to...)
 	for _, r := range set.Args() {
 		// Filter out empty args (this actually happens)
 		if strings.TrimSpace(r) == "" {
@@ -133,6 +272,16 @@ func composeMessage(ctx context.Context, args []string) {
 		recipients = append(recipients, r)
 	}
 
+	// Expand address book aliases/groups in recipients and ccs. Tokens that
+	// aren't known aliases are left untouched.
+	book, err := loadAddressBook()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "ERROR: failed to load address book: %v\n", err)
+		os.Exit(1)
+	}
+	recipients = expandAddresses(book, recipients)
+	ccList := expandAddresses(book, *ccs)
+
 	// Load in-reply-to message
 	var inReplyToMsg *fbb.Message
 	if path := *inReplyTo; path != "" {
@@ -152,34 +301,235 @@ func composeMessage(ctx context.Context, args []string) {
 		}
 	}
 
-	// Check if condition are met for non-interactive compose.
-	if (len(*subject)+len(*attachments)+len(*ccs)+len(recipients)) > 0 && *template != "" && *redirect != "" {
-		noninteractiveComposeMessage(*from, *subject, *attachments, *ccs, recipients, *p2pOnly)
+	// Load forward message
+	var forwardMsg *fbb.Message
+	if path := *forward; path != "" {
+		var err error
+		forwardMsg, err = openMessage(path)
+		if err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	// Check if conditions are met for non-interactive compose: enough CLI
+	// args given, and not using one of the dedicated template/redirect/
+	// forward paths (which have their own non-interactive handling, if any).
+	haveArgs := (len(*subject) + len(*attachments) + len(ccList) + len(recipients)) > 0
+	if haveArgs && *template == "" && *redirect == "" && *forward == "" {
+		noninteractiveComposeMessage(*from, *subject, *attachments, ccList, recipients, *p2pOnly, extraHeaders, *dryRun)
 		return
 	}
 
 	// Use template?
 	if *template != "" {
-		interactiveComposeWithTemplate(*template, inReplyToMsg)
+		// With --field given, render the template non-interactively instead
+		// of dropping into the interactive prompt flow.
+		if len(fields) > 0 {
+			noninteractiveComposeWithTemplate(*template, *from, *subject, ccList, recipients, *p2pOnly, fields, inReplyToMsg, extraHeaders, *dryRun)
+			return
+		}
+		if *dryRun {
+			fmt.Fprint(os.Stderr, "ERROR: --dry-run with --template requires --field!\n")
+			os.Exit(1)
+		}
+		interactiveComposeWithTemplate(*template, inReplyToMsg, extraHeaders)
 		return
 	}
 
 	if *redirect != "" {
-		// Construct a new message with headers from the message to be redirected
-		draftMsg := composeMessageHeader(inReplyToMsg)
+		// Construct a new message with headers from the message to be
+		// redirected. Under --dry-run there's no tty to prompt on, so build
+		// the header from --to/--cc/--subject instead of asking interactively.
+		var draftMsg *fbb.Message
+		if *dryRun {
+			draftMsg = composeMessageHeaderNonInteractive(composeHeaderModeReply, inReplyToMsg, *from, *subject, recipients, ccList)
+		} else {
+			draftMsg = composeMessageHeader(composeHeaderModeReply, inReplyToMsg)
+		}
 		msg, err := redirectMessage(draftMsg, inReplyToMsg)
 		if err != nil {
 			fmt.Fprintf(os.Stderr, "ERROR: %v\n", err)
 			os.Exit(1)
 		}
-		postMessage(msg)
+		addExtraHeaders(msg, extraHeaders)
+		finishCompose(msg, *dryRun)
+	} else if *forward != "" {
+		mode := forwardAttachNone
+		switch {
+		case *attachAll:
+			mode = forwardAttachAll
+		case *attachFull:
+			mode = forwardAttachFull
+		}
+		// Construct a brand new message (new MID, new From) that forwards
+		// forwardMsg. Under --dry-run there's no tty to prompt on, so build the
+		// header from --to/--cc/--subject instead of asking interactively.
+		var draftMsg *fbb.Message
+		if *dryRun {
+			draftMsg = composeMessageHeaderNonInteractive(composeHeaderModeForward, forwardMsg, *from, *subject, recipients, ccList)
+		} else {
+			draftMsg = composeMessageHeader(composeHeaderModeForward, forwardMsg)
+		}
+		msg, err := forwardMessage(draftMsg, forwardMsg, mode)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "ERROR: %v\n", err)
+			os.Exit(1)
+		}
+		if !*dryRun {
+			// Let the user review/edit the body (empty for attach-full) before posting.
+			oldBody, _ := msg.Body()
+			fmt.Printf(`Press ENTER to edit the message body. `)
+			readLine()
+			body, err := composeBody(oldBody)
+			if err != nil {
+				log.Fatal(err)
+			}
+			msg.SetBody(body)
+		}
+		addExtraHeaders(msg, extraHeaders)
+		finishCompose(msg, *dryRun)
 	} else {
+		if *dryRun {
+			fmt.Fprint(os.Stderr, "ERROR: --dry-run requires recipients, --redirect, --forward, or --template with --field!\n")
+			os.Exit(1)
+		}
 		// Interactive compose
-		interactiveComposeMessage(inReplyToMsg)
+		interactiveComposeMessage(inReplyToMsg, extraHeaders)
+	}
+}
+
+// boolsToInt returns the number of true values among bs. Handy for
+// validating that at most one of a set of mutually exclusive flags is set.
+func boolsToInt(bs ...bool) int {
+	n := 0
+	for _, b := range bs {
+		if b {
+			n++
+		}
+	}
+	return n
+}
+
+// managedHeaders are the headers Pat sets itself while composing a message;
+// they can't be overridden with --header.
+var managedHeaders = map[string]bool{
+	"Mid":     true,
+	"Date":    true,
+	"From":    true,
+	"To":      true,
+	"Cc":      true,
+	"Subject": true,
+	"Body":    true,
+}
+
+// parseHeaderFlags parses the raw "Name: Value" strings given via repeatable
+// -H/--header flags into the extra headers to add to the outgoing message.
+func parseHeaderFlags(raw []string) (textproto.MIMEHeader, error) {
+	headers := make(textproto.MIMEHeader, len(raw))
+	for _, h := range raw {
+		name, value, ok := strings.Cut(h, ":")
+		if !ok {
+			return nil, fmt.Errorf("invalid --header %q (expected \"Name: Value\")", h)
+		}
+		name = strings.TrimSpace(name)
+		value = strings.TrimSpace(value)
+		if !isValidHeaderName(name) {
+			return nil, fmt.Errorf("invalid header name %q", name)
+		}
+		if key := textproto.CanonicalMIMEHeaderKey(name); managedHeaders[key] {
+			return nil, fmt.Errorf("header %q is managed by pat and can't be set with --header", name)
+		}
+		if strings.ContainsAny(value, "\r\n") {
+			return nil, fmt.Errorf("invalid value for header %q: must not contain CR or LF", name)
+		}
+		headers.Add(name, value)
+	}
+	return headers, nil
+}
+
+// isValidHeaderName reports whether name is a valid RFC 5322 field name,
+// i.e. a token of printable US-ASCII characters excluding ':'.
+func isValidHeaderName(name string) bool {
+	if name == "" {
+		return false
+	}
+	for _, r := range name {
+		if r <= ' ' || r > '~' || r == ':' {
+			return false
+		}
+	}
+	return true
+}
+
+// messagePreview is the --dry-run --json representation of a constructed
+// message, for shell completion scripts, external UIs and CI lint checks to
+// inspect exactly what pat would send without posting anything.
+type messagePreview struct {
+	MID         string               `json:"mid"`
+	From        string               `json:"from"`
+	To          []string             `json:"to"`
+	Cc          []string             `json:"cc"`
+	Subject     string               `json:"subject"`
+	Body        string               `json:"body"`
+	P2POnly     bool                 `json:"p2p_only"`
+	Attachments []messagePreviewFile `json:"attachments,omitempty"`
+	Headers     map[string][]string  `json:"headers,omitempty"`
+}
+
+// messagePreviewFile describes a single attachment in a messagePreview.
+type messagePreviewFile struct {
+	Name string `json:"name"`
+	Size int    `json:"size"`
+}
+
+// finishCompose posts msg to the outbox, unless dryRun is set, in which case
+// it prints a messagePreview of msg as JSON to stdout instead and returns
+// without posting anything.
+func finishCompose(msg *fbb.Message, dryRun bool) {
+	if !dryRun {
+		postMessage(msg)
+		return
+	}
+
+	body, _ := msg.Body()
+	preview := messagePreview{
+		MID:     msg.MID(),
+		From:    msg.From().String(),
+		Subject: msg.Subject(),
+		Body:    body,
+		P2POnly: msg.Header.Get("X-P2POnly") == "true",
+	}
+	for _, addr := range msg.To() {
+		preview.To = append(preview.To, addr.String())
+	}
+	for _, addr := range msg.Cc() {
+		preview.Cc = append(preview.Cc, addr.String())
+	}
+	for _, f := range msg.Files() {
+		preview.Attachments = append(preview.Attachments, messagePreviewFile{Name: f.Name(), Size: len(f.Data())})
+	}
+	if h := map[string][]string(msg.Header); len(h) > 0 {
+		preview.Headers = h
+	}
+
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(preview); err != nil {
+		fmt.Fprintf(os.Stderr, "ERROR: %v\n", err)
+		os.Exit(1)
 	}
 }
 
-func noninteractiveComposeMessage(from string, subject string, attachments []string, ccs []string, recipients []string, p2pOnly bool) {
+// addExtraHeaders sets the user-provided --header values on msg.
+func addExtraHeaders(msg *fbb.Message, extraHeaders textproto.MIMEHeader) {
+	for name, values := range extraHeaders {
+		for _, v := range values {
+			msg.Header.Add(name, v)
+		}
+	}
+}
+
+func noninteractiveComposeMessage(from string, subject string, attachments []string, ccs []string, recipients []string, p2pOnly bool, extraHeaders textproto.MIMEHeader, dryRun bool) {
 	// We have to verify the args here. Follow the same pattern as main()
 	// We'll allow a missing recipient if CC is present (or vice versa)
 	if len(recipients)+len(ccs) <= 0 {
@@ -222,14 +572,15 @@ func noninteractiveComposeMessage(from string, subject string, attachments []str
 	if p2pOnly {
 		msg.Header.Set("X-P2POnly", "true")
 	}
+	addExtraHeaders(msg, extraHeaders)
 
-	postMessage(msg)
+	finishCompose(msg, dryRun)
 }
 
 // This is currently an alias for interactiveComposeMessage but keeping as a separate
 // call path for the future
 func composeReplyMessage(inReplyToMsg *fbb.Message) {
-	interactiveComposeMessage(inReplyToMsg)
+	interactiveComposeMessage(inReplyToMsg, nil)
 }
 
 func composeBody(template string) (string, error) {
@@ -245,8 +596,12 @@ func composeBody(template string) (string, error) {
 	return body, nil
 }
 
-func interactiveComposeMessage(inReplyToMsg *fbb.Message) {
-	msg := composeMessageHeader(inReplyToMsg)
+func interactiveComposeMessage(inReplyToMsg *fbb.Message, extraHeaders textproto.MIMEHeader) {
+	mode := composeHeaderModeNew
+	if inReplyToMsg != nil {
+		mode = composeHeaderModeReply
+	}
+	msg := composeMessageHeader(mode, inReplyToMsg)
 
 	// Body
 	var template bytes.Buffer
@@ -274,6 +629,7 @@ func interactiveComposeMessage(inReplyToMsg *fbb.Message) {
 			continue
 		}
 	}
+	addExtraHeaders(msg, extraHeaders)
 	fmt.Println(msg)
 	postMessage(msg)
 }
@@ -324,6 +680,74 @@ func redirectMessage(msg *fbb.Message, redirectMsg *fbb.Message) (*fbb.Message,
 	return msg, nil
 }
 
+// forwardAttachMode selects how attachments on the message being forwarded
+// are carried over to the new message built by forwardMessage.
+type forwardAttachMode int
+
+const (
+	// forwardAttachNone quotes the body inline and drops any attachments.
+	forwardAttachNone forwardAttachMode = iota
+	// forwardAttachAll quotes the body inline and copies all attachments
+	// from the source message onto the new one.
+	forwardAttachAll
+	// forwardAttachFull wraps the entire source message as a single
+	// message/rfc822 attachment, leaving the new message's body empty.
+	forwardAttachFull
+)
+
+// forwardMessage builds msg into a forward of fwdMsg. Unlike redirectMessage,
+// the result is a genuinely new message (new MID, new From, user-chosen
+// To/Cc/Subject) rather than a preserved copy of the original.
+func forwardMessage(msg *fbb.Message, fwdMsg *fbb.Message, mode forwardAttachMode) (*fbb.Message, error) {
+	if fwdMsg == nil {
+		return nil, errors.New("There is no message to be forwarded!")
+	}
+
+	if mode == forwardAttachFull {
+		f, err := rfc822Attachment(fwdMsg)
+		if err != nil {
+			return nil, fmt.Errorf("failed to attach source message: %w", err)
+		}
+		msg.AddFile(f)
+		return msg, nil
+	}
+
+	var buf bytes.Buffer
+	writeForwardedBody(&buf, fwdMsg)
+	msg.SetBody(buf.String())
+
+	if mode == forwardAttachAll {
+		for _, f := range fwdMsg.Files() {
+			msg.AddFile(f)
+		}
+	}
+
+	return msg, nil
+}
+
+// writeForwardedBody writes a quoted copy of fwdMsg's body for inclusion in
+// a forwarded message (forwardAttachNone/forwardAttachAll).
+func writeForwardedBody(w io.Writer, fwdMsg *fbb.Message) {
+	fmt.Fprintf(w, "----- Forwarded message from %s on %s -----\n\n", fwdMsg.From(), fwdMsg.Date())
+	body, _ := fwdMsg.Body()
+	scanner := bufio.NewScanner(strings.NewReader(body))
+	for scanner.Scan() {
+		fmt.Fprintln(w, scanner.Text())
+	}
+	fmt.Fprint(w, "\n----- End of forwarded message -----\n")
+}
+
+// rfc822Attachment serializes fwdMsg as a single message/rfc822 file,
+// suitable for attaching the entire source message to a forward (see
+// forwardAttachFull).
+func rfc822Attachment(fwdMsg *fbb.Message) (*fbb.File, error) {
+	var buf bytes.Buffer
+	if _, err := fwdMsg.Write(&buf); err != nil {
+		return nil, err
+	}
+	return fbb.NewFile(fwdMsg.MID()+".eml", buf.Bytes()), nil
+}
+
 func writeMessageCitation(w io.Writer, inReplyToMsg *fbb.Message) {
 	fmt.Fprintf(w, "--- %s %s wrote: ---\n", inReplyToMsg.Date(), inReplyToMsg.From().Addr)
 	body, _ := inReplyToMsg.Body()
@@ -358,8 +782,99 @@ func composeFormReport(ctx context.Context, args []string) {
 	composeMessage(ctx, args)
 }
 
-func interactiveComposeWithTemplate(template string, inReplyToMsg *fbb.Message) {
-	msg := composeMessageHeader(inReplyToMsg)
+// parseFieldFlags parses the raw "Key=Value" strings given via repeatable
+// --field flags into the template variables to pre-populate.
+func parseFieldFlags(raw []string) (map[string]string, error) {
+	fields := make(map[string]string, len(raw))
+	for _, f := range raw {
+		name, value, ok := strings.Cut(f, "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid --field %q (expected \"Key=Value\")", f)
+		}
+		fields[strings.TrimSpace(name)] = value
+	}
+	return fields, nil
+}
+
+// fieldPlaceholderRE matches a "{Name}" template variable placeholder in a
+// rendered form's subject or body.
+var fieldPlaceholderRE = regexp.MustCompile(`\{([A-Za-z0-9_]+)\}`)
+
+// substituteFields replaces every "{Key}" placeholder in text with
+// fields[Key]. Placeholders with no matching field are left untouched in the
+// returned text and reported in missing, so the caller can fail fast instead
+// of posting a message with unanswered template variables.
+func substituteFields(text string, fields map[string]string) (rendered string, missing []string) {
+	rendered = fieldPlaceholderRE.ReplaceAllStringFunc(text, func(placeholder string) string {
+		name := placeholder[1 : len(placeholder)-1]
+		value, ok := fields[name]
+		if !ok {
+			missing = append(missing, name)
+			return placeholder
+		}
+		return value
+	})
+	return rendered, missing
+}
+
+// noninteractiveComposeWithTemplate renders template without prompting,
+// using fields to populate its {Name} variables. It fails fast (non-zero
+// exit) if any variable referenced by the template has no answer in fields.
+// A free-text body read from stdin is made available to the template as the
+// {body} placeholder.
+func noninteractiveComposeWithTemplate(template, from, subject string, ccs []string, recipients []string, p2pOnly bool, fields map[string]string, inReplyToMsg *fbb.Message, extraHeaders textproto.MIMEHeader, dryRun bool) {
+	if len(recipients)+len(ccs) <= 0 {
+		fmt.Fprint(os.Stderr, "ERROR: Missing recipients in non-interactive mode!\n")
+		os.Exit(1)
+	}
+
+	msg := fbb.NewMessage(fbb.Private, fOptions.MyCall)
+	msg.SetFrom(from)
+	for _, to := range recipients {
+		msg.AddTo(to)
+	}
+	for _, cc := range ccs {
+		msg.AddCc(cc)
+	}
+
+	stdinBody, _ := io.ReadAll(os.Stdin)
+	fields["body"] = string(stdinBody)
+
+	formMsg, err := formsMgr.ComposeTemplate(template, subject, inReplyToMsg)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "ERROR: failed to compose message for template: %v\n", err)
+		os.Exit(1)
+	}
+
+	renderedSubject, missingSubject := substituteFields(formMsg.Subject, fields)
+	renderedBody, missingBody := substituteFields(formMsg.Body, fields)
+	if missing := append(missingSubject, missingBody...); len(missing) > 0 {
+		fmt.Fprintf(os.Stderr, "ERROR: missing --field for required template variable(s): %s\n", strings.Join(missing, ", "))
+		os.Exit(1)
+	}
+
+	if subject == "" {
+		subject = renderedSubject
+	}
+	msg.SetSubject(subject)
+	msg.SetBody(renderedBody)
+	for _, f := range formMsg.Attachments {
+		msg.AddFile(f)
+	}
+	if p2pOnly {
+		msg.Header.Set("X-P2POnly", "true")
+	}
+	addExtraHeaders(msg, extraHeaders)
+
+	finishCompose(msg, dryRun)
+}
+
+func interactiveComposeWithTemplate(template string, inReplyToMsg *fbb.Message, extraHeaders textproto.MIMEHeader) {
+	mode := composeHeaderModeNew
+	if inReplyToMsg != nil {
+		mode = composeHeaderModeReply
+	}
+	msg := composeMessageHeader(mode, inReplyToMsg)
 
 	formMsg, err := formsMgr.ComposeTemplate(template, msg.Subject(), inReplyToMsg)
 	if err != nil {
@@ -405,5 +920,6 @@ L:
 		}
 	}
 	msg.SetBody(formMsg.Body)
+	addExtraHeaders(msg, extraHeaders)
 	postMessage(msg)
 }
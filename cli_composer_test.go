@@ -0,0 +1,268 @@
+// Copyright 2016 Martin Hebnes Pedersen (LA5NTA). All rights reserved.
+// Use of this source code is governed by the MIT-license that can be
+// found in the LICENSE file.
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/textproto"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/la5nta/wl2k-go/fbb"
+)
+
+func TestIsValidHeaderName(t *testing.T) {
+	tests := []struct {
+		name string
+		want bool
+	}{
+		{"X-P2POnly", true},
+		{"X-My-Header", true},
+		{"", false},
+		{"Invalid Name", false},
+		{"Invalid:Name", false},
+		{"Invalid\r\nName", false},
+	}
+	for _, tt := range tests {
+		if got := isValidHeaderName(tt.name); got != tt.want {
+			t.Errorf("isValidHeaderName(%q) = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestParseHeaderFlags(t *testing.T) {
+	tests := []struct {
+		name    string
+		raw     []string
+		want    textproto.MIMEHeader
+		wantErr bool
+	}{
+		{"empty", nil, textproto.MIMEHeader{}, false},
+		{
+			name: "single header",
+			raw:  []string{"X-My-Header: value"},
+			want: textproto.MIMEHeader{"X-My-Header": {"value"}},
+		},
+		{
+			name: "repeated header accumulates values",
+			raw:  []string{"X-My-Header: one", "X-My-Header: two"},
+			want: textproto.MIMEHeader{"X-My-Header": {"one", "two"}},
+		},
+		{"missing colon", []string{"X-My-Header value"}, nil, true},
+		{"invalid header name", []string{"Invalid Name: value"}, nil, true},
+		{"managed header rejected", []string{"Subject: value"}, nil, true},
+		{"managed header rejected case-insensitively", []string{"subject: value"}, nil, true},
+		{"CR in value rejected", []string{"X-My-Header: inject\rSecond: bogus"}, nil, true},
+		{"LF in value rejected", []string{"X-My-Header: inject\nSecond: bogus"}, nil, true},
+		{"CRLF in value rejected", []string{"X-My-Header: inject\r\nSecond: bogus"}, nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseHeaderFlags(tt.raw)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseHeaderFlags(%v) error = %v, wantErr %v", tt.raw, err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseHeaderFlags(%v) = %v, want %v", tt.raw, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseFieldFlags(t *testing.T) {
+	tests := []struct {
+		name    string
+		raw     []string
+		want    map[string]string
+		wantErr bool
+	}{
+		{"empty", nil, map[string]string{}, false},
+		{"single field", []string{"Name=N0CALL"}, map[string]string{"Name": "N0CALL"}, false},
+		{"value may contain =", []string{"Url=http://example.com?a=b"}, map[string]string{"Url": "http://example.com?a=b"}, false},
+		{"key is trimmed", []string{" Name =N0CALL"}, map[string]string{"Name": "N0CALL"}, false},
+		{"missing =", []string{"Name"}, nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseFieldFlags(tt.raw)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseFieldFlags(%v) error = %v, wantErr %v", tt.raw, err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseFieldFlags(%v) = %v, want %v", tt.raw, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSubstituteFields(t *testing.T) {
+	tests := []struct {
+		name        string
+		text        string
+		fields      map[string]string
+		wantText    string
+		wantMissing []string
+	}{
+		{
+			name:     "no placeholders",
+			text:     "Hello there",
+			fields:   map[string]string{},
+			wantText: "Hello there",
+		},
+		{
+			name:     "all placeholders answered",
+			text:     "Name: {Name}, Body: {body}",
+			fields:   map[string]string{"Name": "N0CALL", "body": "73"},
+			wantText: "Name: N0CALL, Body: 73",
+		},
+		{
+			name:        "missing field is left in place and reported",
+			text:        "Name: {Name}, Status: {Status}",
+			fields:      map[string]string{"Name": "N0CALL"},
+			wantText:    "Name: N0CALL, Status: {Status}",
+			wantMissing: []string{"Status"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gotText, gotMissing := substituteFields(tt.text, tt.fields)
+			if gotText != tt.wantText {
+				t.Errorf("substituteFields(%q) text = %q, want %q", tt.text, gotText, tt.wantText)
+			}
+			if !reflect.DeepEqual(gotMissing, tt.wantMissing) {
+				t.Errorf("substituteFields(%q) missing = %v, want %v", tt.text, gotMissing, tt.wantMissing)
+			}
+		})
+	}
+}
+
+func TestWriteForwardedBody(t *testing.T) {
+	fwdMsg := fbb.NewMessage(fbb.Private, "N0CALL")
+	fwdMsg.SetFrom("N1CALL")
+	fwdMsg.SetSubject("Test")
+	fwdMsg.SetBody("Hello\nWorld\n")
+
+	var buf bytes.Buffer
+	writeForwardedBody(&buf, fwdMsg)
+	got := buf.String()
+
+	for _, want := range []string{"Forwarded message from N1CALL", "Hello", "World", "End of forwarded message"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("writeForwardedBody() = %q, want it to contain %q", got, want)
+		}
+	}
+}
+
+func TestForwardMessage(t *testing.T) {
+	newForwardMsg := func() *fbb.Message {
+		fwdMsg := fbb.NewMessage(fbb.Private, "N0CALL")
+		fwdMsg.SetFrom("N1CALL")
+		fwdMsg.SetSubject("Test")
+		fwdMsg.SetBody("body text")
+		fwdMsg.AddFile(fbb.NewFile("a.txt", []byte("data")))
+		return fwdMsg
+	}
+
+	t.Run("none quotes body and drops attachments", func(t *testing.T) {
+		msg := fbb.NewMessage(fbb.Private, "N0CALL")
+		got, err := forwardMessage(msg, newForwardMsg(), forwardAttachNone)
+		if err != nil {
+			t.Fatalf("forwardMessage() error = %v", err)
+		}
+		if len(got.Files()) != 0 {
+			t.Errorf("forwardMessage() left %d attachments, want 0", len(got.Files()))
+		}
+		body, _ := got.Body()
+		if !strings.Contains(body, "body text") {
+			t.Errorf("forwardMessage() body = %q, want it to contain the quoted source body", body)
+		}
+	})
+
+	t.Run("all quotes body and copies attachments", func(t *testing.T) {
+		msg := fbb.NewMessage(fbb.Private, "N0CALL")
+		got, err := forwardMessage(msg, newForwardMsg(), forwardAttachAll)
+		if err != nil {
+			t.Fatalf("forwardMessage() error = %v", err)
+		}
+		if len(got.Files()) != 1 {
+			t.Errorf("forwardMessage() kept %d attachments, want 1", len(got.Files()))
+		}
+	})
+
+	t.Run("full attaches source as rfc822 and leaves body untouched", func(t *testing.T) {
+		msg := fbb.NewMessage(fbb.Private, "N0CALL")
+		got, err := forwardMessage(msg, newForwardMsg(), forwardAttachFull)
+		if err != nil {
+			t.Fatalf("forwardMessage() error = %v", err)
+		}
+		if len(got.Files()) != 1 {
+			t.Errorf("forwardMessage() attached %d files, want 1", len(got.Files()))
+		}
+		if body, _ := got.Body(); body != "" {
+			t.Errorf("forwardMessage() body = %q, want empty for attach-full", body)
+		}
+	})
+
+	t.Run("nil source message is an error", func(t *testing.T) {
+		msg := fbb.NewMessage(fbb.Private, "N0CALL")
+		if _, err := forwardMessage(msg, nil, forwardAttachNone); err == nil {
+			t.Error("forwardMessage() with nil source = nil error, want error")
+		}
+	})
+}
+
+func TestFinishComposeDryRunPreview(t *testing.T) {
+	msg := fbb.NewMessage(fbb.Private, "N0CALL")
+	msg.SetFrom("N0CALL")
+	msg.AddTo("N1CALL")
+	msg.AddCc("N2CALL")
+	msg.SetSubject("Test")
+	msg.SetBody("Hello\n")
+	msg.AddFile(fbb.NewFile("a.txt", []byte("data")))
+
+	stdout := os.Stdout
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	os.Stdout = w
+	finishCompose(msg, true)
+	w.Close()
+	os.Stdout = stdout
+
+	out, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var preview messagePreview
+	if err := json.Unmarshal(out, &preview); err != nil {
+		t.Fatalf("finishCompose() dry-run output is not valid JSON: %v\noutput: %s", err, out)
+	}
+
+	want := messagePreview{
+		MID:         msg.MID(),
+		From:        "N0CALL",
+		To:          []string{"N1CALL"},
+		Cc:          []string{"N2CALL"},
+		Subject:     "Test",
+		Body:        "Hello\n",
+		Attachments: []messagePreviewFile{{Name: "a.txt", Size: 4}},
+		Headers:     map[string][]string(msg.Header),
+	}
+	if !reflect.DeepEqual(preview, want) {
+		t.Errorf("finishCompose() dry-run preview = %+v, want %+v", preview, want)
+	}
+}